@@ -0,0 +1,108 @@
+// Copyright 2021-2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package chaininfo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type RollupAddressesChangeKind string
+
+const (
+	AddressChange   RollupAddressesChangeKind = "address-change"
+	ParameterChange RollupAddressesChangeKind = "parameter-change"
+	FieldAdded      RollupAddressesChangeKind = "field-added"
+	FieldRemoved    RollupAddressesChangeKind = "field-removed"
+)
+
+type RollupAddressesChange struct {
+	Field string                    `json:"field"`
+	Kind  RollupAddressesChangeKind `json:"kind"`
+	// Old is empty for an added field and New is empty for a removed one
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+type RollupAddressesDiff struct {
+	// Fields present in either of the outputs, sorted by name
+	Fields  []string
+	Changes []RollupAddressesChange
+}
+
+// DiffRollupAddresses compares two deployment outputs (as written by the deploy tool).
+// The files are compared field by field rather than through RollupAddresses, so that
+// outputs written by older or newer versions of the tool show which fields were added
+// or removed instead of silently dropping them. Changes are sorted by field name.
+func DiffRollupAddresses(oldJson []byte, newJson []byte) (*RollupAddressesDiff, error) {
+	var oldFields, newFields map[string]json.RawMessage
+	if err := json.Unmarshal(oldJson, &oldFields); err != nil {
+		return nil, fmt.Errorf("failed to parse old rollup addresses: %w", err)
+	}
+	if err := json.Unmarshal(newJson, &newFields); err != nil {
+		return nil, fmt.Errorf("failed to parse new rollup addresses: %w", err)
+	}
+	names := make(map[string]struct{})
+	for name := range oldFields {
+		names[name] = struct{}{}
+	}
+	for name := range newFields {
+		names[name] = struct{}{}
+	}
+	sortedNames := make([]string, 0, len(names))
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+
+	diff := &RollupAddressesDiff{Fields: sortedNames}
+	for _, name := range sortedNames {
+		oldValue, inOld := oldFields[name]
+		newValue, inNew := newFields[name]
+		switch {
+		case !inOld:
+			diff.Changes = append(diff.Changes, RollupAddressesChange{Field: name, Kind: FieldAdded, New: displayValue(newValue)})
+		case !inNew:
+			diff.Changes = append(diff.Changes, RollupAddressesChange{Field: name, Kind: FieldRemoved, Old: displayValue(oldValue)})
+		default:
+			oldAddress, oldIsAddress := parseAddress(oldValue)
+			newAddress, newIsAddress := parseAddress(newValue)
+			if oldIsAddress && newIsAddress {
+				if oldAddress != newAddress {
+					diff.Changes = append(diff.Changes, RollupAddressesChange{Field: name, Kind: AddressChange, Old: oldAddress.Hex(), New: newAddress.Hex()})
+				}
+				continue
+			}
+			oldDisplay, newDisplay := displayValue(oldValue), displayValue(newValue)
+			if oldDisplay != newDisplay {
+				diff.Changes = append(diff.Changes, RollupAddressesChange{Field: name, Kind: ParameterChange, Old: oldDisplay, New: newDisplay})
+			}
+		}
+	}
+	return diff, nil
+}
+
+func parseAddress(value json.RawMessage) (common.Address, bool) {
+	var str string
+	if err := json.Unmarshal(value, &str); err != nil || !common.IsHexAddress(str) {
+		return common.Address{}, false
+	}
+	return common.HexToAddress(str), true
+}
+
+func displayValue(value json.RawMessage) string {
+	var str string
+	if err := json.Unmarshal(value, &str); err == nil {
+		return str
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, value); err != nil {
+		return string(value)
+	}
+	return compacted.String()
+}
@@ -0,0 +1,176 @@
+// Copyright 2021-2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package chaininfo
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const baseDeployment = `{
+	"bridge": "0x1111111111111111111111111111111111111111",
+	"inbox": "0x2222222222222222222222222222222222222222",
+	"sequencer-inbox": "0x3333333333333333333333333333333333333333",
+	"rollup": "0x4444444444444444444444444444444444444444",
+	"native-token": "0x0000000000000000000000000000000000000000",
+	"upgrade-executor": "0x5555555555555555555555555555555555555555",
+	"validator-utils": "0x6666666666666666666666666666666666666666",
+	"validator-wallet-creator": "0x7777777777777777777777777777777777777777",
+	"deployed-at": 100
+}`
+
+func modifiedDeployment(t *testing.T, overrides map[string]interface{}) []byte {
+	t.Helper()
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(baseDeployment), &fields); err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range overrides {
+		if value == nil {
+			delete(fields, name)
+		} else {
+			fields[name] = value
+		}
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDiffRollupAddresses(t *testing.T) {
+	newRollup := "0x8888888888888888888888888888888888888888"
+	lowerCaseBridge := "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	tests := []struct {
+		name     string
+		old      []byte
+		new      []byte
+		expected []RollupAddressesChange
+	}{
+		{
+			name: "unchanged",
+			old:  []byte(baseDeployment),
+			new:  modifiedDeployment(t, nil),
+		},
+		{
+			name: "address case is ignored",
+			old:  modifiedDeployment(t, map[string]interface{}{"bridge": lowerCaseBridge}),
+			new:  modifiedDeployment(t, map[string]interface{}{"bridge": common.HexToAddress(lowerCaseBridge).Hex()}),
+		},
+		{
+			name: "address change",
+			old:  []byte(baseDeployment),
+			new:  modifiedDeployment(t, map[string]interface{}{"rollup": newRollup}),
+			expected: []RollupAddressesChange{
+				{Field: "rollup", Kind: AddressChange, Old: "0x4444444444444444444444444444444444444444", New: common.HexToAddress(newRollup).Hex()},
+			},
+		},
+		{
+			name: "parameter change",
+			old:  []byte(baseDeployment),
+			new:  modifiedDeployment(t, map[string]interface{}{"deployed-at": 200}),
+			expected: []RollupAddressesChange{
+				{Field: "deployed-at", Kind: ParameterChange, Old: "100", New: "200"},
+			},
+		},
+		{
+			name: "field added and removed",
+			old:  []byte(baseDeployment),
+			new:  modifiedDeployment(t, map[string]interface{}{"inbox": nil, "stake-token": "0x9999999999999999999999999999999999999999"}),
+			expected: []RollupAddressesChange{
+				{Field: "inbox", Kind: FieldRemoved, Old: "0x2222222222222222222222222222222222222222"},
+				{Field: "stake-token", Kind: FieldAdded, New: "0x9999999999999999999999999999999999999999"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff, err := DiffRollupAddresses(test.old, test.new)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(diff.Changes, test.expected) {
+				t.Errorf("unexpected changes: got %+v, expected %+v", diff.Changes, test.expected)
+			}
+		})
+	}
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// deploy_older.json was written before native-token and upgrade-executor were added,
+// and spells addresses with their checksum case rather than in lower case.
+func TestDiffRollupAddressesFixtures(t *testing.T) {
+	diff, err := DiffRollupAddresses(readFixture(t, "deploy_older.json"), readFixture(t, "deploy_current.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []RollupAddressesChange{
+		{Field: "deployed-at", Kind: ParameterChange, Old: "100", New: "200"},
+		{Field: "native-token", Kind: FieldAdded, New: "0x0000000000000000000000000000000000000000"},
+		{Field: "rollup", Kind: AddressChange, Old: "0x4444444444444444444444444444444444444444", New: "0x8888888888888888888888888888888888888888"},
+		{Field: "upgrade-executor", Kind: FieldAdded, New: "0x5555555555555555555555555555555555555555"},
+	}
+	if !reflect.DeepEqual(diff.Changes, expected) {
+		t.Errorf("unexpected changes: got %+v, expected %+v", diff.Changes, expected)
+	}
+	expectedFields := []string{
+		"bridge", "deployed-at", "inbox", "native-token", "rollup", "sequencer-inbox",
+		"upgrade-executor", "validator-utils", "validator-wallet-creator",
+	}
+	if !reflect.DeepEqual(diff.Fields, expectedFields) {
+		t.Errorf("unexpected fields: got %v, expected %v", diff.Fields, expectedFields)
+	}
+
+	// Comparing in the other direction reports the fields as removed
+	diff, err = DiffRollupAddresses(readFixture(t, "deploy_current.json"), readFixture(t, "deploy_older.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var removed []string
+	for _, change := range diff.Changes {
+		if change.Kind == FieldRemoved {
+			removed = append(removed, change.Field)
+		}
+	}
+	if !reflect.DeepEqual(removed, []string{"native-token", "upgrade-executor"}) {
+		t.Errorf("unexpected removed fields %v", removed)
+	}
+}
+
+func TestDiffRollupAddressesInvalidJson(t *testing.T) {
+	if _, err := DiffRollupAddresses([]byte("not json"), []byte(baseDeployment)); err == nil {
+		t.Error("expected an error for an invalid old file")
+	}
+	if _, err := DiffRollupAddresses([]byte(baseDeployment), []byte("[]")); err == nil {
+		t.Error("expected an error for a new file that isn't an object")
+	}
+}
+
+func TestRollupAddressesChangeJsonKeepsEmptyValues(t *testing.T) {
+	data, err := json.Marshal(RollupAddressesChange{Field: "name", Kind: ParameterChange, Old: "", New: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if old, ok := fields["old"]; !ok || old != "" {
+		t.Errorf("expected an empty old value in %s", data)
+	}
+}
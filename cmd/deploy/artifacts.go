@@ -0,0 +1,290 @@
+// Copyright 2021-2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/cmd/chaininfo"
+	"github.com/offchainlabs/nitro/cmd/exitcode"
+)
+
+const (
+	artifactsManifestName = "manifest.json"
+	deployManifestName    = "deploy-manifest.json"
+	artifactsLatestName   = "latest"
+	failureReportName     = "deploy-failure.json"
+)
+
+type manifestEntry struct {
+	Name   string `json:"name"`
+	Sha256 string `json:"sha256"`
+}
+
+type artifactsManifest struct {
+	CreatedAt time.Time       `json:"createdAt"`
+	Artifacts []manifestEntry `json:"artifacts"`
+}
+
+type failureReport struct {
+	Step  string    `json:"step"`
+	Error string    `json:"error"`
+	Time  time.Time `json:"time"`
+	// Set if the rollup was deployed before the failure, so its addresses aren't lost
+	RollupAddresses *chaininfo.RollupAddresses `json:"rollup,omitempty"`
+}
+
+// deploymentOutputs is where the deploy tool writes its outputs.
+type deploymentOutputs struct {
+	deployFile    string
+	chainInfoFile string
+	// If set, the outputs are written into a new timestamped directory under it
+	artifactsDir string
+}
+
+func (o *deploymentOutputs) reportDir() string {
+	if o.artifactsDir != "" {
+		return o.artifactsDir
+	}
+	return filepath.Dir(o.deployFile)
+}
+
+// write writes the deployed addresses and chain info together, along with a
+// manifest of their checksums, and removes any failure report left by an earlier
+// run. If that fails, the failure report includes the deployed addresses and
+// the returned error is classified as exitcode.PartialCompletion.
+func (o *deploymentOutputs) write(deployed *chaininfo.RollupAddresses, chainInfo chaininfo.ChainInfo, now time.Time) error {
+	failed := func(err error) error {
+		writeFailureReport(o.reportDir(), "write artifacts", err, deployed)
+		return &exitcode.Error{
+			Code:       exitcode.PartialCompletion,
+			Step:       "write artifacts",
+			Err:        err,
+			Checkpoint: filepath.Join(o.reportDir(), failureReportName),
+			ResumeHint: "the rollup was deployed and its addresses are in the checkpoint, don't deploy it again",
+		}
+	}
+	artifacts := newStagedArtifacts()
+	deployData, err := json.Marshal(deployed)
+	if err != nil {
+		return failed(err)
+	}
+	log.Info("deployed rollup", "addresses", string(deployData))
+	artifacts.stage(o.deployFile, deployData)
+	chainInfo.RollupAddresses = deployed
+	chainsInfoJson, err := json.Marshal([]chaininfo.ChainInfo{chainInfo})
+	if err != nil {
+		return failed(err)
+	}
+	artifacts.stage(o.chainInfoFile, chainsInfoJson)
+	if o.artifactsDir != "" {
+		runDir, err := artifacts.flushToDir(o.artifactsDir, now)
+		if err != nil {
+			return failed(err)
+		}
+		log.Info("wrote deployment artifacts", "dir", runDir)
+	} else {
+		manifestDir := filepath.Dir(o.deployFile)
+		err := artifacts.stageManifest(filepath.Join(manifestDir, deployManifestName), now, func(path string) string {
+			if rel, err := filepath.Rel(manifestDir, path); err == nil {
+				return rel
+			}
+			return path
+		})
+		if err == nil {
+			err = artifacts.flush()
+		}
+		if err != nil {
+			return failed(err)
+		}
+	}
+	if err := os.Remove(filepath.Join(o.reportDir(), failureReportName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warn("failed to remove stale deployment failure report", "err", err)
+	}
+	return nil
+}
+
+// stagedArtifacts collects the deployment outputs in memory so that nothing
+// is written to disk until every deployment step has succeeded.
+type stagedArtifacts struct {
+	paths []string
+	data  map[string][]byte
+}
+
+func newStagedArtifacts() *stagedArtifacts {
+	return &stagedArtifacts{data: make(map[string][]byte)}
+}
+
+func (a *stagedArtifacts) stage(path string, data []byte) {
+	if _, exists := a.data[path]; !exists {
+		a.paths = append(a.paths, path)
+	}
+	a.data[path] = data
+}
+
+// stageManifest stages a manifest at manifestPath listing every artifact staged so
+// far under the name returned by name, along with its checksum.
+func (a *stagedArtifacts) stageManifest(manifestPath string, now time.Time, name func(path string) string) error {
+	manifest := artifactsManifest{CreatedAt: now.UTC()}
+	for _, path := range a.paths {
+		if path == manifestPath {
+			return fmt.Errorf("artifact %v clashes with the manifest", path)
+		}
+		checksum := sha256.Sum256(a.data[path])
+		manifest.Artifacts = append(manifest.Artifacts, manifestEntry{Name: name(path), Sha256: hex.EncodeToString(checksum[:])})
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	a.stage(manifestPath, manifestData)
+	return nil
+}
+
+// flush writes every staged artifact to a temporary file and only renames them
+// into place once all of the writes succeeded. Existing targets are backed up
+// first, so that if one of the renames fails the targets already replaced are
+// restored and the previous artifacts are left untouched.
+func (a *stagedArtifacts) flush() error {
+	var tmpPaths, backups []string
+	cleanup := func() {
+		for _, tmp := range tmpPaths {
+			_ = os.Remove(tmp)
+		}
+		for _, backup := range backups {
+			if backup != "" {
+				_ = os.Remove(backup)
+			}
+		}
+	}
+	for _, path := range a.paths {
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, a.data[path], 0600); err != nil {
+			cleanup()
+			return fmt.Errorf("failed to write artifact %v: %w", path, err)
+		}
+		tmpPaths = append(tmpPaths, tmp)
+	}
+	for _, path := range a.paths {
+		backup, err := backupArtifact(path)
+		if err != nil {
+			cleanup()
+			return fmt.Errorf("failed to back up artifact %v: %w", path, err)
+		}
+		backups = append(backups, backup)
+	}
+	for i, path := range a.paths {
+		if err := os.Rename(tmpPaths[i], path); err != nil {
+			for j := 0; j < i; j++ {
+				if backups[j] == "" {
+					_ = os.Remove(a.paths[j])
+				} else if restoreErr := os.Rename(backups[j], a.paths[j]); restoreErr != nil {
+					log.Error("failed to restore previous artifact", "path", a.paths[j], "backup", backups[j], "err", restoreErr)
+					backups[j] = ""
+				}
+			}
+			tmpPaths = tmpPaths[i:]
+			cleanup()
+			return fmt.Errorf("failed to move artifact %v into place: %w", path, err)
+		}
+	}
+	tmpPaths = nil
+	cleanup()
+	return nil
+}
+
+// backupArtifact hard links an existing artifact to a ".bak" path next to it, and
+// returns that path. It returns an empty path if there's no artifact to back up.
+func backupArtifact(path string) (string, error) {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && info.IsDir()) {
+		// Nothing to restore; moving the artifact over a directory fails anyway
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	backup := path + ".bak"
+	if err := os.Remove(backup); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	if err := os.Link(path, backup); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
+
+// flushToDir writes the staged artifacts, keyed by their base names, into a
+// fresh timestamped directory under artifactsDir together with a manifest of
+// their checksums. The "latest" link is only updated once everything is on disk.
+func (a *stagedArtifacts) flushToDir(artifactsDir string, now time.Time) (string, error) {
+	runDir := filepath.Join(artifactsDir, now.UTC().Format("20060102T150405Z"))
+	if err := os.MkdirAll(artifactsDir, 0700); err != nil {
+		return "", err
+	}
+	if err := os.Mkdir(runDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create artifacts directory: %w", err)
+	}
+	inDir := newStagedArtifacts()
+	for _, path := range a.paths {
+		name := filepath.Base(path)
+		if _, exists := inDir.data[filepath.Join(runDir, name)]; exists {
+			return "", fmt.Errorf("multiple artifacts named %v", name)
+		}
+		inDir.stage(filepath.Join(runDir, name), a.data[path])
+	}
+	if err := inDir.stageManifest(filepath.Join(runDir, artifactsManifestName), now, filepath.Base); err != nil {
+		return "", err
+	}
+	if err := inDir.flush(); err != nil {
+		return "", err
+	}
+	if err := updateLatestLink(artifactsDir, runDir); err != nil {
+		return "", fmt.Errorf("artifacts written to %v but failed to update latest link: %w", runDir, err)
+	}
+	return runDir, nil
+}
+
+func updateLatestLink(artifactsDir, runDir string) error {
+	latest := filepath.Join(artifactsDir, artifactsLatestName)
+	tmp := latest + ".tmp"
+	if err := os.Remove(tmp); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Symlink(filepath.Base(runDir), tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, latest)
+}
+
+// writeFailureReport records which step of the deployment failed, and the rollup's
+// addresses if it was already deployed. It's best effort, as it's only called
+// when the deployment is already failing.
+func writeFailureReport(dir string, step string, failure error, deployed *chaininfo.RollupAddresses) {
+	report := failureReport{
+		Step:            step,
+		Error:           failure.Error(),
+		Time:            time.Now().UTC(),
+		RollupAddresses: deployed,
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(dir, 0700)
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, failureReportName), data, 0600)
+	}
+	if err != nil {
+		log.Error("failed to write deployment failure report", "err", err)
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2021-2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/cmd/chaininfo"
+	"github.com/offchainlabs/nitro/cmd/exitcode"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestStagedArtifactsFlush(t *testing.T) {
+	dir := t.TempDir()
+	deployPath := filepath.Join(dir, "deploy.json")
+	infoPath := filepath.Join(dir, "l2_chain_info.json")
+
+	artifacts := newStagedArtifacts()
+	artifacts.stage(deployPath, []byte("deploy"))
+	artifacts.stage(infoPath, []byte("info"))
+	if _, err := os.Stat(deployPath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("staging an artifact shouldn't write it")
+	}
+	if err := artifacts.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, deployPath); got != "deploy" {
+		t.Errorf("unexpected deploy.json contents %q", got)
+	}
+	if got := readFile(t, infoPath); got != "info" {
+		t.Errorf("unexpected chain info contents %q", got)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected only the two artifacts, found %v files", len(entries))
+	}
+}
+
+func TestStagedArtifactsFlushFailureKeepsPrevious(t *testing.T) {
+	dir := t.TempDir()
+	deployPath := filepath.Join(dir, "deploy.json")
+	if err := os.WriteFile(deployPath, []byte("previous"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	artifacts := newStagedArtifacts()
+	artifacts.stage(deployPath, []byte("new"))
+	artifacts.stage(filepath.Join(dir, "missing", "l2_chain_info.json"), []byte("info"))
+	if err := artifacts.flush(); err == nil {
+		t.Fatal("expected flush into a missing directory to fail")
+	}
+	if got := readFile(t, deployPath); got != "previous" {
+		t.Errorf("previous deploy.json was overwritten with %q", got)
+	}
+	if _, err := os.Stat(deployPath + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Error("temporary file was left behind")
+	}
+}
+
+func TestStagedArtifactsFlushRenameFailureRestoresPrevious(t *testing.T) {
+	dir := t.TempDir()
+	deployPath := filepath.Join(dir, "deploy.json")
+	if err := os.WriteFile(deployPath, []byte("previous"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// A non-empty directory in place of the chain info makes its rename fail
+	// after deploy.json was already moved into place
+	infoPath := filepath.Join(dir, "l2_chain_info.json")
+	if err := os.MkdirAll(filepath.Join(infoPath, "child"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	artifacts := newStagedArtifacts()
+	artifacts.stage(deployPath, []byte("new"))
+	artifacts.stage(infoPath, []byte("info"))
+	if err := artifacts.flush(); err == nil {
+		t.Fatal("expected moving the chain info over a directory to fail")
+	}
+	if got := readFile(t, deployPath); got != "previous" {
+		t.Errorf("previous deploy.json wasn't restored, found %q", got)
+	}
+	for _, leftover := range []string{deployPath + ".tmp", deployPath + ".bak", infoPath + ".tmp"} {
+		if _, err := os.Stat(leftover); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%v was left behind", leftover)
+		}
+	}
+}
+
+func TestStagedArtifactsFlushToDir(t *testing.T) {
+	dir := t.TempDir()
+	first := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	artifacts := newStagedArtifacts()
+	artifacts.stage("deploy.json", []byte("first"))
+	runDir, err := artifacts.flushToDir(dir, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(dir, artifactsLatestName, "deploy.json")); got != "first" {
+		t.Errorf("unexpected latest deploy.json contents %q", got)
+	}
+	var manifest artifactsManifest
+	if err := json.Unmarshal([]byte(readFile(t, filepath.Join(runDir, artifactsManifestName))), &manifest); err != nil {
+		t.Fatal(err)
+	}
+	checksum := sha256.Sum256([]byte("first"))
+	if len(manifest.Artifacts) != 1 || manifest.Artifacts[0].Name != "deploy.json" || manifest.Artifacts[0].Sha256 != hex.EncodeToString(checksum[:]) {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+
+	// Reusing the same timestamp must fail without touching the latest link
+	artifacts.stage("deploy.json", []byte("second"))
+	if _, err := artifacts.flushToDir(dir, first); err == nil {
+		t.Fatal("expected flush into an existing run directory to fail")
+	}
+	if got := readFile(t, filepath.Join(dir, artifactsLatestName, "deploy.json")); got != "first" {
+		t.Errorf("latest link moved after a failed flush, deploy.json is %q", got)
+	}
+
+	if _, err := artifacts.flushToDir(dir, first.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(dir, artifactsLatestName, "deploy.json")); got != "second" {
+		t.Errorf("unexpected latest deploy.json contents %q", got)
+	}
+}
+
+func TestWriteFailureReport(t *testing.T) {
+	dir := t.TempDir()
+	writeFailureReport(dir, "deploy on l1", errors.New("boom"), nil)
+	var report failureReport
+	if err := json.Unmarshal([]byte(readFile(t, filepath.Join(dir, failureReportName))), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Step != "deploy on l1" || report.Error != "boom" || report.RollupAddresses != nil {
+		t.Errorf("unexpected failure report %+v", report)
+	}
+}
+
+func TestWriteFailureReportCreatesDir(t *testing.T) {
+	// The artifacts directory doesn't exist yet on a first run
+	dir := filepath.Join(t.TempDir(), "artifacts")
+	writeFailureReport(dir, "deploy on l1", errors.New("boom"), nil)
+	if report := readFailureReport(t, dir); report.Step != "deploy on l1" {
+		t.Errorf("unexpected failure report %+v", report)
+	}
+}
+
+func readFailureReport(t *testing.T, dir string) failureReport {
+	t.Helper()
+	var report failureReport
+	if err := json.Unmarshal([]byte(readFile(t, filepath.Join(dir, failureReportName))), &report); err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func TestDeploymentOutputsWriteFailureKeepsPrevious(t *testing.T) {
+	dir := t.TempDir()
+	outputs := deploymentOutputs{
+		deployFile:    filepath.Join(dir, "deploy.json"),
+		chainInfoFile: filepath.Join(dir, "missing", "l2_chain_info.json"),
+	}
+	if err := os.WriteFile(outputs.deployFile, []byte("previous"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	deployed := &chaininfo.RollupAddresses{DeployedAt: 7}
+	err := outputs.write(deployed, chaininfo.ChainInfo{}, time.Now())
+	if err == nil {
+		t.Fatal("expected writing the chain info into a missing directory to fail")
+	}
+	if got := readFile(t, outputs.deployFile); got != "previous" {
+		t.Errorf("previous deploy.json was overwritten with %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, deployManifestName)); !errors.Is(err, os.ErrNotExist) {
+		t.Error("manifest was written for a failed write")
+	}
+	var classified *exitcode.Error
+	if !errors.As(err, &classified) || classified.Code != exitcode.PartialCompletion || classified.Checkpoint != filepath.Join(dir, failureReportName) {
+		t.Errorf("expected a partial completion pointing at the failure report, got %v", err)
+	}
+	report := readFailureReport(t, dir)
+	if report.Step != "write artifacts" {
+		t.Errorf("unexpected failure report %+v", report)
+	}
+	if report.RollupAddresses == nil || *report.RollupAddresses != *deployed {
+		t.Errorf("deployed addresses can't be recovered from the failure report %+v", report)
+	}
+}
+
+func TestDeploymentOutputsWriteToDirFailureKeepsLatest(t *testing.T) {
+	dir := t.TempDir()
+	outputs := deploymentOutputs{
+		deployFile:    "deploy.json",
+		chainInfoFile: "l2_chain_info.json",
+		artifactsDir:  dir,
+	}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	first := &chaininfo.RollupAddresses{DeployedAt: 1}
+	if err := outputs.write(first, chaininfo.ChainInfo{}, now); err != nil {
+		t.Fatal(err)
+	}
+	latestDeploy := filepath.Join(dir, artifactsLatestName, "deploy.json")
+	previous := readFile(t, latestDeploy)
+
+	// Reusing the run directory makes writing the artifacts fail
+	if err := outputs.write(&chaininfo.RollupAddresses{DeployedAt: 2}, chaininfo.ChainInfo{}, now); err == nil {
+		t.Fatal("expected writing into an existing run directory to fail")
+	}
+	if got := readFile(t, latestDeploy); got != previous {
+		t.Errorf("latest deploy.json changed after a failed write: %q", got)
+	}
+	report := readFailureReport(t, dir)
+	if report.Step != "write artifacts" || report.RollupAddresses == nil || report.RollupAddresses.DeployedAt != 2 {
+		t.Errorf("unexpected failure report %+v", report)
+	}
+}
+
+func TestDeploymentOutputsWriteRemovesStaleFailureReport(t *testing.T) {
+	dir := t.TempDir()
+	outputs := deploymentOutputs{
+		deployFile:    filepath.Join(dir, "deploy.json"),
+		chainInfoFile: filepath.Join(dir, "l2_chain_info.json"),
+	}
+	writeFailureReport(dir, "deploy on l1", errors.New("boom"), nil)
+	if err := outputs.write(&chaininfo.RollupAddresses{}, chaininfo.ChainInfo{}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, failureReportName)); !errors.Is(err, os.ErrNotExist) {
+		t.Error("stale failure report wasn't removed after a successful deployment")
+	}
+	var chainsInfo []chaininfo.ChainInfo
+	if err := json.Unmarshal([]byte(readFile(t, outputs.chainInfoFile)), &chainsInfo); err != nil {
+		t.Fatal(err)
+	}
+	if len(chainsInfo) != 1 || chainsInfo[0].RollupAddresses == nil {
+		t.Errorf("unexpected chain info %+v", chainsInfo)
+	}
+	var manifest artifactsManifest
+	if err := json.Unmarshal([]byte(readFile(t, filepath.Join(dir, deployManifestName))), &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Artifacts) != 2 || manifest.Artifacts[0].Name != "deploy.json" || manifest.Artifacts[1].Name != "l2_chain_info.json" {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+}
@@ -6,6 +6,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"math/big"
@@ -14,6 +15,7 @@ import (
 	"time"
 
 	"github.com/offchainlabs/nitro/cmd/chaininfo"
+	"github.com/offchainlabs/nitro/cmd/exitcode"
 	"github.com/offchainlabs/nitro/cmd/genericconf"
 	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
 	"github.com/offchainlabs/nitro/util/headerreader"
@@ -30,6 +32,15 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		os.Exit(runDiff(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	os.Exit(exitcode.Report(os.Stderr, mainImpl()))
+}
+
+// mainImpl deploys the rollup. Its errors are classified with the exitcode package,
+// so that wrapper scripts can tell misconfiguration from retryable failures.
+func mainImpl() error {
 	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
 	glogger.Verbosity(log.LvlDebug)
 	log.Root().SetHandler(glogger)
@@ -56,6 +67,7 @@ func main() {
 	l2ChainConfig := flag.String("l2chainconfig", "l2_chain_config.json", "L2 chain config json file")
 	l2ChainName := flag.String("l2chainname", "", "L2 chain name (will be included in chain info output json file)")
 	l2ChainInfo := flag.String("l2chaininfo", "l2_chain_info.json", "L2 chain info output json file")
+	artifactsDir := flag.String("artifactsdir", "", "if set, write all output files into a new timestamped directory here and point its \"latest\" link at it")
 	authorizevalidators := flag.Uint64("authorizevalidators", 0, "Number of validators to preemptively authorize")
 	txTimeout := flag.Duration("txtimeout", 10*time.Minute, "Timeout when waiting for a transaction to be included in a block")
 	prod := flag.Bool("prod", false, "Whether to configure the rollup for production or testing")
@@ -64,13 +76,16 @@ func main() {
 	l1ChainId := new(big.Int).SetUint64(*l1ChainIdUint)
 	maxDataSize := new(big.Int).SetUint64(*maxDataSizeUint)
 
+	invalidConfig := func(err error) error {
+		return exitcode.New(exitcode.InvalidConfig, "check config", err)
+	}
 	if *prod {
 		if *wasmmoduleroot == "" {
-			panic("must specify wasm module root when launching prod chain")
+			return invalidConfig(errors.New("must specify wasm module root when launching prod chain"))
 		}
 	}
 	if *l2ChainName == "" {
-		panic("must specify l2 chain name")
+		return invalidConfig(errors.New("must specify l2 chain name"))
 	}
 
 	wallet := genericconf.WalletConfig{
@@ -83,28 +98,28 @@ func main() {
 	if err != nil {
 		flag.Usage()
 		log.Error("error reading keystore")
-		panic(err)
+		return exitcode.New(exitcode.InvalidConfig, "open wallet", err)
 	}
 
 	l1client, err := ethclient.Dial(*l1conn)
 	if err != nil {
 		flag.Usage()
 		log.Error("error creating l1client")
-		panic(err)
+		return exitcode.New(exitcode.RetryableInfrastructure, "connect to l1", err)
 	}
 
 	if !common.IsHexAddress(*sequencerAddressString) && len(*sequencerAddressString) > 0 {
-		panic("specified sequencer address is invalid")
+		return invalidConfig(errors.New("specified sequencer address is invalid"))
 	}
 	sequencerAddress := common.HexToAddress(*sequencerAddressString)
 
 	if !common.IsHexAddress(*ownerAddressString) {
-		panic("please specify a valid rollup owner address")
+		return invalidConfig(errors.New("please specify a valid rollup owner address"))
 	}
 	ownerAddress := common.HexToAddress(*ownerAddressString)
 
 	if *prod && !common.IsHexAddress(*loserEscrowAddressString) {
-		panic("please specify a valid loser escrow address")
+		return invalidConfig(errors.New("please specify a valid loser escrow address"))
 	}
 
 	var batchPosters []common.Address
@@ -118,7 +133,7 @@ func main() {
 			batchPosters = append(batchPosters, common.HexToAddress(address))
 		}
 		if len(batchPosters) != len(batchPostersArr) {
-			panic("found at least one invalid address in batch posters array")
+			return invalidConfig(errors.New("found at least one invalid address in batch posters array"))
 		}
 	}
 	if len(batchPosters) == 0 {
@@ -131,7 +146,7 @@ func main() {
 		batchPosterManagerAddress = common.HexToAddress(*batchPosterManagerAddressString)
 	} else {
 		if len(*batchPosterManagerAddressString) > 0 {
-			panic("please specify a valid batch poster manager address")
+			return invalidConfig(errors.New("please specify a valid batch poster manager address"))
 		}
 		log.Info("batch poster manager address was empty, defaulting to owner address")
 		batchPosterManagerAddress = ownerAddress
@@ -139,21 +154,21 @@ func main() {
 
 	loserEscrowAddress := common.HexToAddress(*loserEscrowAddressString)
 	if sequencerAddress != (common.Address{}) && ownerAddress != l1TransactionOpts.From {
-		panic("cannot specify sequencer address if owner is not deployer")
+		return invalidConfig(errors.New("cannot specify sequencer address if owner is not deployer"))
 	}
 
 	var moduleRoot common.Hash
 	if *wasmmoduleroot == "" {
 		locator, err := server_common.NewMachineLocator(*wasmrootpath)
 		if err != nil {
-			panic(err)
+			return exitcode.New(exitcode.PreflightFailure, "locate machines", err)
 		}
 		moduleRoot = locator.LatestWasmModuleRoot()
 	} else {
 		moduleRoot = common.HexToHash(*wasmmoduleroot)
 	}
 	if moduleRoot == (common.Hash{}) {
-		panic("wasmModuleRoot not found")
+		return exitcode.New(exitcode.PreflightFailure, "locate machines", errors.New("wasmModuleRoot not found"))
 	}
 
 	headerReaderConfig := headerreader.DefaultConfig
@@ -161,18 +176,18 @@ func main() {
 
 	chainConfigJson, err := os.ReadFile(*l2ChainConfig)
 	if err != nil {
-		panic(fmt.Errorf("failed to read l2 chain config file: %w", err))
+		return invalidConfig(fmt.Errorf("failed to read l2 chain config file: %w", err))
 	}
 	var chainConfig params.ChainConfig
 	err = json.Unmarshal(chainConfigJson, &chainConfig)
 	if err != nil {
-		panic(fmt.Errorf("failed to deserialize chain config: %w", err))
+		return invalidConfig(fmt.Errorf("failed to deserialize chain config: %w", err))
 	}
 
 	arbSys, _ := precompilesgen.NewArbSys(types.ArbSysAddress, l1client)
 	l1Reader, err := headerreader.New(ctx, l1client, func() *headerreader.Config { return &headerReaderConfig }, arbSys)
 	if err != nil {
-		panic(fmt.Errorf("failed to create header reader: %w", err))
+		return exitcode.New(exitcode.PreflightFailure, "create header reader", err)
 	}
 	l1Reader.Start(ctx)
 	defer l1Reader.StopAndWait()
@@ -190,33 +205,27 @@ func main() {
 		maxDataSize,
 		*isUsingFeeToken,
 	)
+	outputs := deploymentOutputs{
+		deployFile:    *outfile,
+		chainInfoFile: *l2ChainInfo,
+		artifactsDir:  *artifactsDir,
+	}
 	if err != nil {
+		writeFailureReport(outputs.reportDir(), "deploy on l1", err, nil)
 		flag.Usage()
 		log.Error("error deploying on l1")
-		panic(err)
-	}
-	deployData, err := json.Marshal(deployedAddresses)
-	if err != nil {
-		panic(err)
-	}
-	if err := os.WriteFile(*outfile, deployData, 0600); err != nil {
-		panic(err)
+		code := exitcode.Failure
+		if exitcode.IsRetryable(err) {
+			code = exitcode.RetryableInfrastructure
+		}
+		return exitcode.New(code, "deploy on l1", err)
 	}
 	parentChainIsArbitrum := l1Reader.IsParentChainArbitrum()
-	chainsInfo := []chaininfo.ChainInfo{
-		{
-			ChainName:             *l2ChainName,
-			ParentChainId:         l1ChainId.Uint64(),
-			ParentChainIsArbitrum: &parentChainIsArbitrum,
-			ChainConfig:           &chainConfig,
-			RollupAddresses:       deployedAddresses,
-		},
-	}
-	chainsInfoJson, err := json.Marshal(chainsInfo)
-	if err != nil {
-		panic(err)
-	}
-	if err := os.WriteFile(*l2ChainInfo, chainsInfoJson, 0600); err != nil {
-		panic(err)
+	chainInfo := chaininfo.ChainInfo{
+		ChainName:             *l2ChainName,
+		ParentChainId:         l1ChainId.Uint64(),
+		ParentChainIsArbitrum: &parentChainIsArbitrum,
+		ChainConfig:           &chainConfig,
 	}
+	return outputs.write(deployedAddresses, chainInfo, time.Now())
 }
@@ -0,0 +1,111 @@
+// Copyright 2021-2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/offchainlabs/nitro/cmd/chaininfo"
+	"github.com/offchainlabs/nitro/cmd/exitcode"
+)
+
+type diffOutput struct {
+	Changes          []chaininfo.RollupAddressesChange `json:"changes"`
+	UnexpectedFields []string                          `json:"unexpected-changes,omitempty"`
+}
+
+// runDiff implements `deploy diff <old.json> <new.json>`. It exits with
+// exitcode.VerificationFailure if any of the fields listed in -expect-unchanged differ
+// between the two deployment outputs, and prints a failure record to stderr on failure.
+func runDiff(args []string, stdout io.Writer, stderr io.Writer) int {
+	return exitcode.Report(stderr, diffDeployments(args, stdout, stderr))
+}
+
+func diffDeployments(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	expectUnchanged := flags.String("expect-unchanged", "", "comma separated fields (e.g. rollup,bridge) that must not change")
+	jsonOutput := flags.Bool("json", false, "print the diff as json instead of a table")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: deploy diff [options] <old.json> <new.json>")
+		flags.PrintDefaults()
+		fmt.Fprintf(stderr, "exit codes: %v if -expect-unchanged fields changed, %v for invalid arguments, %v if a file can't be read or parsed, %v if the diff can't be written\n",
+			exitcode.VerificationFailure, exitcode.InvalidConfig, exitcode.PreflightFailure, exitcode.Failure)
+	}
+	if err := flags.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return exitcode.New(exitcode.InvalidConfig, "parse arguments", err)
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return exitcode.New(exitcode.InvalidConfig, "parse arguments", fmt.Errorf("expected 2 files, got %v", flags.NArg()))
+	}
+	oldJson, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return exitcode.New(exitcode.PreflightFailure, "read old deployment", err)
+	}
+	newJson, err := os.ReadFile(flags.Arg(1))
+	if err != nil {
+		return exitcode.New(exitcode.PreflightFailure, "read new deployment", err)
+	}
+	diff, err := chaininfo.DiffRollupAddresses(oldJson, newJson)
+	if err != nil {
+		return exitcode.New(exitcode.PreflightFailure, "parse deployments", err)
+	}
+	changes := diff.Changes
+
+	known := make(map[string]bool)
+	for _, field := range diff.Fields {
+		known[field] = true
+	}
+	changed := make(map[string]bool)
+	for _, change := range changes {
+		changed[change.Field] = true
+	}
+	output := diffOutput{Changes: changes}
+	if *expectUnchanged != "" {
+		for _, field := range strings.Split(*expectUnchanged, ",") {
+			field = strings.TrimSpace(field)
+			if !known[field] {
+				return exitcode.New(exitcode.InvalidConfig, "parse arguments", fmt.Errorf("unknown field %q in -expect-unchanged", field))
+			}
+			if changed[field] {
+				output.UnexpectedFields = append(output.UnexpectedFields, field)
+			}
+		}
+	}
+
+	if *jsonOutput {
+		if output.Changes == nil {
+			output.Changes = []chaininfo.RollupAddressesChange{}
+		}
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(output); err != nil {
+			return exitcode.New(exitcode.Failure, "write diff", err)
+		}
+	} else if len(changes) == 0 {
+		fmt.Fprintln(stdout, "no changes")
+	} else {
+		writer := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "FIELD\tKIND\tOLD\tNEW")
+		for _, change := range changes {
+			fmt.Fprintf(writer, "%v\t%v\t%v\t%v\n", change.Field, change.Kind, change.Old, change.New)
+		}
+		_ = writer.Flush()
+	}
+	if len(output.UnexpectedFields) > 0 {
+		err := fmt.Errorf("fields expected to be unchanged were changed: %v", strings.Join(output.UnexpectedFields, ","))
+		return exitcode.New(exitcode.VerificationFailure, "check unchanged fields", err)
+	}
+	return nil
+}
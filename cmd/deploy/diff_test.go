@@ -0,0 +1,115 @@
+// Copyright 2021-2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/offchainlabs/nitro/cmd/chaininfo"
+	"github.com/offchainlabs/nitro/cmd/exitcode"
+)
+
+func writeDeployment(t *testing.T, dir string, name string, rollup string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	data := `{"bridge":"0x1111111111111111111111111111111111111111","rollup":"` + rollup + `","deployed-at":1}`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func lastFailureRecord(t *testing.T, stderr *bytes.Buffer) exitcode.FailureRecord {
+	t.Helper()
+	lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+	var record exitcode.FailureRecord
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &record); err != nil {
+		t.Fatalf("last line of stderr isn't a failure record: %v", err)
+	}
+	return record
+}
+
+func TestRunDiff(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := writeDeployment(t, dir, "old.json", "0x2222222222222222222222222222222222222222")
+	newPath := writeDeployment(t, dir, "new.json", "0x3333333333333333333333333333333333333333")
+	corruptPath := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corruptPath, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+	}{
+		{"no expectations", []string{oldPath, newPath}, exitcode.Success},
+		{"help", []string{"-h"}, exitcode.Success},
+		{"unchanged field", []string{"-expect-unchanged", "bridge", oldPath, newPath}, exitcode.Success},
+		{"changed field", []string{"-expect-unchanged", "bridge,rollup", oldPath, newPath}, exitcode.VerificationFailure},
+		{"unknown field", []string{"-expect-unchanged", "bridg", oldPath, newPath}, exitcode.InvalidConfig},
+		{"missing argument", []string{oldPath}, exitcode.InvalidConfig},
+		{"missing file", []string{oldPath, filepath.Join(dir, "missing.json")}, exitcode.PreflightFailure},
+		{"corrupt file", []string{oldPath, corruptPath}, exitcode.PreflightFailure},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if exitCode := runDiff(test.args, &stdout, &stderr); exitCode != test.exitCode {
+				t.Errorf("got exit code %v, expected %v (stderr: %v)", exitCode, test.exitCode, stderr.String())
+			}
+			if test.exitCode == exitcode.Success {
+				return
+			}
+			record := lastFailureRecord(t, &stderr)
+			if record.ExitCode != test.exitCode || record.Category != exitcode.Category(test.exitCode) || record.Step == "" || record.Error == "" {
+				t.Errorf("unexpected failure record %+v", record)
+			}
+		})
+	}
+}
+
+func TestRunDiffJson(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := writeDeployment(t, dir, "old.json", "0x2222222222222222222222222222222222222222")
+	newPath := writeDeployment(t, dir, "new.json", "0x3333333333333333333333333333333333333333")
+
+	var stdout, stderr bytes.Buffer
+	if exitCode := runDiff([]string{"-json", "-expect-unchanged", "rollup", oldPath, newPath}, &stdout, &stderr); exitCode != exitcode.VerificationFailure {
+		t.Fatalf("got exit code %v, expected %v", exitCode, exitcode.VerificationFailure)
+	}
+	var output diffOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Changes) != 1 || output.Changes[0].Field != "rollup" || output.Changes[0].Kind != chaininfo.AddressChange {
+		t.Errorf("unexpected changes %+v", output.Changes)
+	}
+	if len(output.UnexpectedFields) != 1 || output.UnexpectedFields[0] != "rollup" {
+		t.Errorf("unexpected fields %v", output.UnexpectedFields)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestRunDiffOutputFailure(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := writeDeployment(t, dir, "old.json", "0x2222222222222222222222222222222222222222")
+	newPath := writeDeployment(t, dir, "new.json", "0x3333333333333333333333333333333333333333")
+
+	var stderr bytes.Buffer
+	if exitCode := runDiff([]string{"-json", oldPath, newPath}, failingWriter{}, &stderr); exitCode != exitcode.Failure {
+		t.Errorf("got exit code %v, expected %v", exitCode, exitcode.Failure)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2021-2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package exitcode defines the exit codes command line tools use to tell wrapper
+// scripts why they failed, and the failure record they print before exiting.
+package exitcode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+const (
+	Success = 0
+	// Failure is used for errors that don't fall into any other category
+	Failure          = 1
+	InvalidConfig    = 2
+	PreflightFailure = 3
+	// RetryableInfrastructure means running the tool again may succeed
+	RetryableInfrastructure = 4
+	// PartialCompletion means some of the work was done and recorded in a checkpoint
+	PartialCompletion   = 5
+	VerificationFailure = 6
+)
+
+var categories = map[int]string{
+	Failure:                 "unclassified",
+	InvalidConfig:           "invalid-config",
+	PreflightFailure:        "preflight-failure",
+	RetryableInfrastructure: "retryable-infrastructure",
+	PartialCompletion:       "partial-completion",
+	VerificationFailure:     "verification-failure",
+}
+
+// Category returns the name of the failure category for an exit code.
+func Category(code int) string {
+	if category, ok := categories[code]; ok {
+		return category
+	}
+	return categories[Failure]
+}
+
+// Error is a failure classified into one of the exit codes.
+type Error struct {
+	Code int
+	Step string
+	Err  error
+	// Optional path to whatever was written to allow resuming
+	Checkpoint string
+	ResumeHint string
+}
+
+func New(code int, step string, err error) *Error {
+	return &Error{Code: code, Step: step, Err: err}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %v", e.Step, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// FailureRecord is printed as a single line of JSON when a tool fails.
+type FailureRecord struct {
+	Category   string `json:"category"`
+	ExitCode   int    `json:"exit-code"`
+	Step       string `json:"step,omitempty"`
+	Error      string `json:"error"`
+	Checkpoint string `json:"checkpoint,omitempty"`
+	ResumeHint string `json:"resume-hint,omitempty"`
+}
+
+// Classify returns the exit code and failure record for err. Errors that weren't
+// classified with an Error are reported as retryable if they're timeouts or
+// network errors, and as unclassified failures otherwise.
+func Classify(err error) (int, FailureRecord) {
+	record := FailureRecord{Error: err.Error()}
+	code := Failure
+	var classified *Error
+	if errors.As(err, &classified) {
+		code = classified.Code
+		record.Step = classified.Step
+		record.Error = classified.Err.Error()
+		record.Checkpoint = classified.Checkpoint
+		record.ResumeHint = classified.ResumeHint
+	} else if IsRetryable(err) {
+		code = RetryableInfrastructure
+	}
+	record.Category = Category(code)
+	record.ExitCode = code
+	return code, record
+}
+
+// IsRetryable returns whether err is a timeout or network error.
+func IsRetryable(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr)
+}
+
+// Report prints the failure record for err to w and returns the exit code to use.
+// It returns Success without printing anything if err is nil.
+func Report(w io.Writer, err error) int {
+	if err == nil {
+		return Success
+	}
+	code, record := Classify(err)
+	data, marshalErr := json.Marshal(record)
+	if marshalErr != nil {
+		data = []byte(fmt.Sprintf(`{"category":%q,"exit-code":%d}`, record.Category, code))
+	}
+	fmt.Fprintln(w, string(data))
+	return code
+}
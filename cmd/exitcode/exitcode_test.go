@@ -0,0 +1,80 @@
+// Copyright 2021-2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package exitcode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		code     int
+		expected FailureRecord
+	}{
+		{
+			name:     "unclassified",
+			err:      errors.New("boom"),
+			code:     Failure,
+			expected: FailureRecord{Category: "unclassified", ExitCode: Failure, Error: "boom"},
+		},
+		{
+			name:     "timeout",
+			err:      fmt.Errorf("waiting for receipt: %w", context.DeadlineExceeded),
+			code:     RetryableInfrastructure,
+			expected: FailureRecord{Category: "retryable-infrastructure", ExitCode: RetryableInfrastructure, Error: "waiting for receipt: context deadline exceeded"},
+		},
+		{
+			name: "wrapped classified error",
+			err: fmt.Errorf("deploy: %w", &Error{
+				Code:       PartialCompletion,
+				Step:       "write artifacts",
+				Err:        errors.New("disk full"),
+				Checkpoint: "deploy-failure.json",
+				ResumeHint: "see the checkpoint",
+			}),
+			code: PartialCompletion,
+			expected: FailureRecord{
+				Category:   "partial-completion",
+				ExitCode:   PartialCompletion,
+				Step:       "write artifacts",
+				Error:      "disk full",
+				Checkpoint: "deploy-failure.json",
+				ResumeHint: "see the checkpoint",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if code := Report(&out, test.err); code != test.code {
+				t.Errorf("got exit code %v, expected %v", code, test.code)
+			}
+			if strings.Count(out.String(), "\n") != 1 {
+				t.Errorf("expected a single line failure record, got %q", out.String())
+			}
+			var record FailureRecord
+			if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+				t.Fatal(err)
+			}
+			if record != test.expected {
+				t.Errorf("got failure record %+v, expected %+v", record, test.expected)
+			}
+		})
+	}
+}
+
+func TestReportSuccess(t *testing.T) {
+	var out bytes.Buffer
+	if code := Report(&out, nil); code != Success || out.Len() != 0 {
+		t.Errorf("unexpected exit code %v and output %q for success", code, out.String())
+	}
+}
@@ -20,10 +20,13 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/solgen/go/rollupgen"
 )
 
+var wasmModuleRootChangedCounter = metrics.NewRegisteredCounter("arb/staker/wasm_module_root/changed", nil)
+
 type ConfirmType uint8
 
 const (
@@ -55,6 +58,8 @@ type L1Validator struct {
 	txStreamer         TransactionStreamerInterface
 	blockValidator     *BlockValidator
 	lastWasmModuleRoot common.Hash
+	// The root last read from the rollup, tracked even without a block validator
+	observedWasmModuleRoot common.Hash
 }
 
 func NewL1Validator(
@@ -112,13 +117,14 @@ func (v *L1Validator) Initialize(ctx context.Context) error {
 }
 
 func (v *L1Validator) updateBlockValidatorModuleRoot(ctx context.Context) error {
-	if v.blockValidator == nil {
-		return nil
-	}
 	moduleRoot, err := v.rollup.WasmModuleRoot(v.getCallOpts(ctx))
 	if err != nil {
 		return err
 	}
+	v.observeWasmModuleRoot(moduleRoot)
+	if v.blockValidator == nil {
+		return nil
+	}
 	if moduleRoot != v.lastWasmModuleRoot {
 		err := v.blockValidator.SetCurrentWasmModuleRoot(moduleRoot)
 		if err != nil {
@@ -131,6 +137,19 @@ func (v *L1Validator) updateBlockValidatorModuleRoot(ctx context.Context) error
 	return nil
 }
 
+// observeWasmModuleRoot warns and counts in the arb/staker/wasm_module_root/changed
+// metric if the rollup's wasmModuleRoot differs from the one read last time, and
+// returns whether it did.
+func (v *L1Validator) observeWasmModuleRoot(moduleRoot common.Hash) bool {
+	changed := v.observedWasmModuleRoot != (common.Hash{}) && moduleRoot != v.observedWasmModuleRoot
+	if changed {
+		log.Warn("rollup wasmModuleRoot changed", "previous", v.observedWasmModuleRoot, "new", moduleRoot)
+		wasmModuleRootChangedCounter.Inc(1)
+	}
+	v.observedWasmModuleRoot = moduleRoot
+	return changed
+}
+
 func (v *L1Validator) resolveTimedOutChallenges(ctx context.Context) (*types.Transaction, error) {
 	challengesToEliminate, _, err := v.validatorUtils.TimedOutChallenges(v.getCallOpts(ctx), v.rollupAddress, 0, 10)
 	if err != nil {
@@ -0,0 +1,73 @@
+// Copyright 2021-2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package staker
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/solgen/go/rollupgen"
+)
+
+// rollupRootCaller answers every contract call with the same wasmModuleRoot.
+type rollupRootCaller struct {
+	root common.Hash
+}
+
+func (c *rollupRootCaller) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (c *rollupRootCaller) CallContract(context.Context, ethereum.CallMsg, *big.Int) ([]byte, error) {
+	return c.root.Bytes(), nil
+}
+
+func TestObserveWasmModuleRoot(t *testing.T) {
+	// No block validator, as on a watchtower
+	v := &L1Validator{}
+	first := common.HexToHash("0x01")
+	second := common.HexToHash("0x02")
+	if v.observeWasmModuleRoot(first) {
+		Fail(t, "first observed root reported as a change")
+	}
+	if v.observeWasmModuleRoot(first) {
+		Fail(t, "unchanged root reported as a change")
+	}
+	if !v.observeWasmModuleRoot(second) {
+		Fail(t, "root rotation wasn't reported")
+	}
+	if v.observeWasmModuleRoot(second) {
+		Fail(t, "rotation reported again for the same root")
+	}
+	if v.lastWasmModuleRoot != (common.Hash{}) {
+		Fail(t, "observing a root shouldn't change the root applied to the block validator")
+	}
+}
+
+func TestUpdateModuleRootWithoutBlockValidator(t *testing.T) {
+	caller := &rollupRootCaller{root: common.HexToHash("0x01")}
+	rollupCaller, err := rollupgen.NewRollupUserLogicCaller(common.Address{}, caller)
+	Require(t, err)
+	v := &L1Validator{
+		rollup: &RollupWatcher{RollupUserLogic: &rollupgen.RollupUserLogic{RollupUserLogicCaller: *rollupCaller}},
+	}
+	ctx := context.Background()
+
+	Require(t, v.updateBlockValidatorModuleRoot(ctx))
+	if v.observedWasmModuleRoot != caller.root {
+		Fail(t, "root wasn't recorded without a block validator, got", v.observedWasmModuleRoot)
+	}
+	caller.root = common.HexToHash("0x02")
+	Require(t, v.updateBlockValidatorModuleRoot(ctx))
+	if v.observedWasmModuleRoot != caller.root {
+		Fail(t, "rotated root wasn't recorded without a block validator, got", v.observedWasmModuleRoot)
+	}
+	if v.lastWasmModuleRoot != (common.Hash{}) {
+		Fail(t, "root was applied without a block validator")
+	}
+}
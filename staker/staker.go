@@ -33,6 +33,7 @@ import (
 
 var (
 	stakerBalanceGauge              = metrics.NewRegisteredGaugeFloat64("arb/staker/balance", nil)
+	stakerLowBalanceGauge           = metrics.NewRegisteredGauge("arb/staker/balance/low", nil)
 	stakerAmountStakedGauge         = metrics.NewRegisteredGauge("arb/staker/amount_staked", nil)
 	stakerLatestStakedNodeGauge     = metrics.NewRegisteredGauge("arb/staker/staked_node", nil)
 	stakerLatestConfirmedNodeGauge  = metrics.NewRegisteredGauge("arb/staker/confirmed_node", nil)
@@ -88,6 +89,7 @@ type L1ValidatorConfig struct {
 	DataPoster                dataposter.DataPosterConfig `koanf:"data-poster" reload:"hot"`
 	RedisUrl                  string                      `koanf:"redis-url"`
 	ExtraGas                  uint64                      `koanf:"extra-gas" reload:"hot"`
+	LowBalanceWarning         float64                     `koanf:"low-balance-warning"`
 	Dangerous                 DangerousConfig             `koanf:"dangerous"`
 	ParentChainWallet         genericconf.WalletConfig    `koanf:"parent-chain-wallet"`
 
@@ -131,6 +133,9 @@ func (c *L1ValidatorConfig) Validate() error {
 		return err
 	}
 	c.strategy = strategy
+	if c.LowBalanceWarning < 0 {
+		return errors.New("validator low balance warning must not be negative")
+	}
 	if len(c.GasRefunderAddress) > 0 && !common.IsHexAddress(c.GasRefunderAddress) {
 		return errors.New("invalid validator gas refunder address")
 	}
@@ -138,6 +143,12 @@ func (c *L1ValidatorConfig) Validate() error {
 	return nil
 }
 
+// belowLowBalanceWarning returns whether the given balance, in ether, should be
+// warned about. A threshold of 0 disables the warning.
+func (c *L1ValidatorConfig) belowLowBalanceWarning(balanceEther float64) bool {
+	return c.LowBalanceWarning > 0 && balanceEther < c.LowBalanceWarning
+}
+
 var DefaultL1ValidatorConfig = L1ValidatorConfig{
 	Enable:                    true,
 	Strategy:                  "Watchtower",
@@ -154,6 +165,7 @@ var DefaultL1ValidatorConfig = L1ValidatorConfig{
 	DataPoster:                dataposter.DefaultDataPosterConfigForValidator,
 	RedisUrl:                  "",
 	ExtraGas:                  50000,
+	LowBalanceWarning:         0,
 	Dangerous:                 DefaultDangerousConfig,
 	ParentChainWallet:         DefaultValidatorL1WalletConfig,
 }
@@ -174,6 +186,7 @@ var TestL1ValidatorConfig = L1ValidatorConfig{
 	DataPoster:                dataposter.TestDataPosterConfigForValidator,
 	RedisUrl:                  "",
 	ExtraGas:                  50000,
+	LowBalanceWarning:         0,
 	Dangerous:                 DefaultDangerousConfig,
 	ParentChainWallet:         DefaultValidatorL1WalletConfig,
 }
@@ -201,6 +214,7 @@ func L1ValidatorConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.String(prefix+".gas-refunder-address", DefaultL1ValidatorConfig.GasRefunderAddress, "The gas refunder contract address (optional)")
 	f.String(prefix+".redis-url", DefaultL1ValidatorConfig.RedisUrl, "redis url for L1 validator")
 	f.Uint64(prefix+".extra-gas", DefaultL1ValidatorConfig.ExtraGas, "use this much more gas than estimation says is necessary to post transactions")
+	f.Float64(prefix+".low-balance-warning", DefaultL1ValidatorConfig.LowBalanceWarning, "log an error and set the arb/staker/balance/low metric when the validator's tx sender balance falls below this amount of ether (0 to disable)")
 	dataposter.DataPosterConfigAddOptions(prefix+".data-poster", f, dataposter.DefaultDataPosterConfigForValidator)
 	DangerousConfigAddOptions(prefix+".dangerous", f)
 	genericconf.WalletConfigAddOptions(prefix+".parent-chain-wallet", f, DefaultL1ValidatorConfig.ParentChainWallet.Pathname)
@@ -250,6 +264,7 @@ type Staker struct {
 	inboxReader             InboxReaderInterface
 	statelessBlockValidator *StatelessBlockValidator
 	fatalErr                chan<- error
+	lowBalance              bool
 }
 
 type ValidatorWalletInterface interface {
@@ -1045,5 +1060,19 @@ func (s *Staker) updateStakerBalanceMetric(ctx context.Context) {
 		log.Error("error getting staker balance", "txSenderAddress", *txSenderAddress, "err", err)
 		return
 	}
-	stakerBalanceGauge.Update(arbmath.BalancePerEther(balance))
+	balanceEther := arbmath.BalancePerEther(balance)
+	stakerBalanceGauge.Update(balanceEther)
+	lowBalance := s.config.belowLowBalanceWarning(balanceEther)
+	if lowBalance {
+		stakerLowBalanceGauge.Update(1)
+	} else {
+		stakerLowBalanceGauge.Update(0)
+	}
+	if lowBalance && !s.lowBalance {
+		// The staker can't post moves without gas, so surface this before it silently stalls mid-challenge
+		log.Error("validator balance is below the configured warning threshold", "txSenderAddress", *txSenderAddress, "balance", balanceEther, "threshold", s.config.LowBalanceWarning)
+	} else if !lowBalance && s.lowBalance {
+		log.Info("validator balance is back above the configured warning threshold", "txSenderAddress", *txSenderAddress, "balance", balanceEther)
+	}
+	s.lowBalance = lowBalance
 }
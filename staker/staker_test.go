@@ -0,0 +1,36 @@
+// Copyright 2021-2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package staker
+
+import (
+	"testing"
+)
+
+func TestL1ValidatorConfigLowBalanceWarning(t *testing.T) {
+	config := TestL1ValidatorConfig
+	config.LowBalanceWarning = 0.5
+	Require(t, config.Validate())
+
+	config.LowBalanceWarning = -1
+	if err := config.Validate(); err == nil {
+		Fail(t, "negative low balance warning was accepted")
+	}
+}
+
+func TestL1ValidatorConfigBelowLowBalanceWarning(t *testing.T) {
+	config := TestL1ValidatorConfig
+	if config.belowLowBalanceWarning(0) {
+		Fail(t, "warning fired with the threshold disabled")
+	}
+	config.LowBalanceWarning = 0.5
+	if !config.belowLowBalanceWarning(0.1) {
+		Fail(t, "warning didn't fire below the threshold")
+	}
+	if config.belowLowBalanceWarning(0.5) {
+		Fail(t, "warning fired at the threshold")
+	}
+	if config.belowLowBalanceWarning(2) {
+		Fail(t, "warning fired above the threshold")
+	}
+}